@@ -0,0 +1,336 @@
+// Command jsonhandler-gen generates zero-reflection http.Handler
+// implementations for functions that are otherwise wrapped by
+// jsonhandler.NewHandler at runtime.
+//
+// Usage:
+//
+//	jsonhandler-gen [packages]
+//
+// packages is a list of go list patterns; it defaults to "./...".
+//
+// jsonhandler-gen looks for two kinds of targets in the given packages:
+//
+//   - calls to jsonhandler.NewHandler(fn) where fn is a package-level
+//     function
+//   - package-level functions with a "jsonhandler:generate" comment
+//
+// For every target whose signature matches one of the shapes below, it
+// writes a <package>_jsonhandler.go file next to the target containing a
+// hand-written http.Handler with the payload decode, dispatch, response
+// encode and error mapping inlined, and registers it with
+// jsonhandler.RegisterGenerated from an init function. At runtime,
+// jsonhandler.NewHandler prefers this registered handler over the
+// reflection-based implementation, falling back to reflection for anything
+// jsonhandler-gen did not recognize.
+//
+// Only the following shapes are currently generated:
+//
+//	func(context.Context)
+//	func(context.Context) error
+//	func(context.Context) T
+//	func(context.Context) (T, error)
+//	func(context.Context, T)
+//	func(context.Context, T) error
+//	func(context.Context, T) T2
+//	func(context.Context, T) (T2, error)
+//
+// Method-valued handlers (for example NewHandler(h.ServeJSON)), the
+// []ResponseFunc return shape, and the streaming (<-chan T, error) /
+// (iter.Seq[T], error) shapes are not generated; they continue to be served
+// through reflection, which is the only dispatch path that knows how to
+// flush a stream incrementally.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	marker          = "jsonhandler:generate"
+	newHandlerFunc  = "NewHandler"
+	jsonhandlerPath = "github.com/marcusirgens/jsonhandler"
+)
+
+// target describes a function jsonhandler-gen will emit a handler for.
+type target struct {
+	Name      string
+	PayloadT  string
+	ReturnT   string
+	HasReturn bool
+	HasError  bool
+}
+
+func main() {
+	patterns := os.Args[1:]
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "jsonhandler-gen:", err)
+		os.Exit(1)
+	}
+
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			fmt.Fprintf(os.Stderr, "jsonhandler-gen: %s: %v\n", pkg.PkgPath, pkg.Errors[0])
+			os.Exit(1)
+		}
+		if err := generatePackage(pkg); err != nil {
+			fmt.Fprintf(os.Stderr, "jsonhandler-gen: %s: %v\n", pkg.PkgPath, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func generatePackage(pkg *packages.Package) error {
+	targets, err := findTargets(pkg)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Package string
+		Targets []target
+	}{Package: pkg.Name, Targets: targets}); err != nil {
+		return err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting generated source: %w", err)
+	}
+
+	dir := filepath.Dir(pkg.GoFiles[0])
+	out := filepath.Join(dir, pkg.Name+"_jsonhandler.go")
+	return os.WriteFile(out, src, 0o644)
+}
+
+// findTargets walks pkg's syntax trees looking for marker comments and
+// jsonhandler.NewHandler call sites, and resolves each to a describable
+// target. Functions passed to NewHandler whose signature jsonhandler-gen
+// does not recognize are silently skipped; they keep working through the
+// reflection-based handler. A function carrying the marker comment with an
+// unrecognized signature is reported as an error instead.
+func findTargets(pkg *packages.Package) ([]target, error) {
+	seen := map[*types.Func]bool{}
+	var targets []target
+
+	add := func(fn *types.Func, required bool) error {
+		if fn == nil || seen[fn] {
+			return nil
+		}
+		t, err := describeTarget(fn)
+		if err != nil {
+			if required {
+				return fmt.Errorf("%s: %w", fn.Name(), err)
+			}
+			return nil
+		}
+		seen[fn] = true
+		targets = append(targets, t)
+		return nil
+	}
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv != nil || fd.Doc == nil || !hasMarker(fd.Doc) {
+				continue
+			}
+			fn, _ := pkg.TypesInfo.Defs[fd.Name].(*types.Func)
+			if err := add(fn, true); err != nil {
+				return nil, err
+			}
+		}
+
+		var inspectErr error
+		ast.Inspect(file, func(n ast.Node) bool {
+			if inspectErr != nil {
+				return false
+			}
+			call, ok := n.(*ast.CallExpr)
+			if !ok || !isNewHandlerCall(pkg, call) || len(call.Args) != 1 {
+				return true
+			}
+			ident, ok := call.Args[0].(*ast.Ident)
+			if !ok {
+				// method values and closures aren't generated yet.
+				return true
+			}
+			fn, _ := pkg.TypesInfo.Uses[ident].(*types.Func)
+			if err := add(fn, false); err != nil {
+				inspectErr = err
+				return false
+			}
+			return true
+		})
+		if inspectErr != nil {
+			return nil, inspectErr
+		}
+	}
+
+	return targets, nil
+}
+
+func hasMarker(doc *ast.CommentGroup) bool {
+	for _, c := range doc.List {
+		if strings.Contains(c.Text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNewHandlerCall(pkg *packages.Package, call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != newHandlerFunc {
+		return false
+	}
+	fn, ok := pkg.TypesInfo.Uses[sel.Sel].(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == jsonhandlerPath
+}
+
+func describeTarget(fn *types.Func) (target, error) {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok {
+		return target{}, fmt.Errorf("not a function")
+	}
+
+	params := sig.Params()
+	if params.Len() == 0 || params.Len() > 2 {
+		return target{}, fmt.Errorf("unsupported parameter count %d", params.Len())
+	}
+	if types.TypeString(params.At(0).Type(), nil) != "context.Context" {
+		return target{}, fmt.Errorf("first parameter must be context.Context")
+	}
+
+	t := target{Name: fn.Name()}
+	if params.Len() == 2 {
+		t.PayloadT = types.TypeString(params.At(1).Type(), types.RelativeTo(fn.Pkg()))
+	}
+
+	results := sig.Results()
+	switch results.Len() {
+	case 0:
+	case 1:
+		if isError(results.At(0).Type()) {
+			t.HasError = true
+		} else {
+			t.HasReturn = true
+			t.ReturnT = types.TypeString(results.At(0).Type(), types.RelativeTo(fn.Pkg()))
+		}
+	case 2:
+		if !isError(results.At(1).Type()) {
+			return target{}, fmt.Errorf("unsupported return shape")
+		}
+		if isStreamType(results.At(0).Type()) {
+			// streaming handlers only work through the reflection-based
+			// dispatch in ServeHTTP, which knows how to flush a chan or
+			// iter.Seq incrementally; a generated handler would instead
+			// try (and fail) to json.Marshal the chan/func value itself.
+			return target{}, fmt.Errorf("streaming return shape %s is not supported by jsonhandler-gen", types.TypeString(results.At(0).Type(), nil))
+		}
+		t.HasReturn = true
+		t.HasError = true
+		t.ReturnT = types.TypeString(results.At(0).Type(), types.RelativeTo(fn.Pkg()))
+	default:
+		return target{}, fmt.Errorf("unsupported return count %d", results.Len())
+	}
+
+	return t, nil
+}
+
+func isError(t types.Type) bool {
+	iface, ok := types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+	return ok && types.Implements(t, iface)
+}
+
+// isStreamType reports whether t is one of the streaming return shapes
+// jsonhandler's reflection-based ServeHTTP recognizes (a channel, or an
+// iter.Seq), which jsonhandler-gen does not generate a handler for.
+func isStreamType(t types.Type) bool {
+	if ch, ok := t.Underlying().(*types.Chan); ok {
+		return ch.Dir() != types.SendOnly
+	}
+	if _, ok := t.Underlying().(*types.Signature); ok {
+		return strings.HasPrefix(types.TypeString(t, nil), "iter.Seq[")
+	}
+	return false
+}
+
+var tmpl = template.Must(template.New("jsonhandler-gen").Parse(`// Code generated by jsonhandler-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/marcusirgens/jsonhandler"
+)
+{{range .Targets}}
+type {{.Name}}JSONHandler struct{}
+
+func ({{.Name}}JSONHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := jsonhandler.WithRequest(r.Context(), r)
+	{{if .PayloadT -}}
+	var in {{.PayloadT}}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		_ = r.Body.Close()
+		jsonhandler.WriteError(ctx, w, jsonhandler.Errorf(http.StatusBadRequest, "Bad request: %w", err))
+		return
+	}
+	_ = r.Body.Close()
+	if v, ok := any(&in).(jsonhandler.Validator); ok {
+		if err := v.Validate(); err != nil {
+			jsonhandler.WriteError(ctx, w, jsonhandler.ValidationError(err))
+			return
+		}
+	}
+	{{end -}}
+	{{if and .HasReturn .HasError -}}
+	out, err := {{.Name}}(ctx{{if .PayloadT}}, in{{end}})
+	if err != nil {
+		jsonhandler.WriteError(ctx, w, err)
+		return
+	}
+	jsonhandler.WriteJSON(w, http.StatusOK, out)
+	{{- else if .HasError}}
+	if err := {{.Name}}(ctx{{if .PayloadT}}, in{{end}}); err != nil {
+		jsonhandler.WriteError(ctx, w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	{{- else if .HasReturn}}
+	out := {{.Name}}(ctx{{if .PayloadT}}, in{{end}})
+	jsonhandler.WriteJSON(w, http.StatusOK, out)
+	{{- else}}
+	{{.Name}}(ctx{{if .PayloadT}}, in{{end}})
+	w.WriteHeader(http.StatusOK)
+	{{- end}}
+}
+
+func init() {
+	jsonhandler.RegisterGenerated({{.Name}}, {{.Name}}JSONHandler{})
+}
+{{end}}`))
@@ -0,0 +1,53 @@
+package jsonhandler
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+)
+
+// Codec decodes request payloads and encodes response payloads for a single
+// media type. Register one with WithCodec or WithCodecs to let a handler
+// created by NewHandlerWith speak something other than JSON.
+type Codec interface {
+	// Decode reads a value of v's underlying type from r.
+	Decode(r io.Reader, v interface{}) error
+	// Encode writes v to w.
+	Encode(w io.Writer, v interface{}) error
+	// ContentType is the Content-Type this Codec produces, e.g.
+	// "application/json; charset=utf-8". It is also used, stripped of any
+	// parameters, to match the codec against a request's Content-Type and
+	// Accept headers.
+	ContentType() string
+}
+
+// mediaTypeOf returns c's content type with any parameters (such as
+// "charset") stripped, for use as a codec lookup key.
+func mediaTypeOf(c Codec) string {
+	mt, _, err := mime.ParseMediaType(c.ContentType())
+	if err != nil {
+		return c.ContentType()
+	}
+	return mt
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (jsonCodec) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+// JSONCodec is the Codec jsonhandler uses when no other codec has been
+// registered for a handler, or when content negotiation finds no better
+// match.
+var JSONCodec Codec = jsonCodec{}
@@ -0,0 +1,25 @@
+package jsonhandler
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Decode(r io.Reader, v interface{}) error {
+	return msgpack.NewDecoder(r).Decode(v)
+}
+
+func (msgpackCodec) Encode(w io.Writer, v interface{}) error {
+	return msgpack.NewEncoder(w).Encode(v)
+}
+
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+// MsgpackCodec encodes and decodes application/msgpack payloads. Register it
+// with WithCodec to let a handler accept or produce MessagePack.
+var MsgpackCodec Codec = msgpackCodec{}
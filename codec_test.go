@@ -0,0 +1,78 @@
+package jsonhandler_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marcusirgens/jsonhandler"
+)
+
+func Test_codecNegotiation(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	h := jsonhandler.NewHandlerWith(func(_ context.Context, in payload) (payload, error) {
+		return in, nil
+	}, jsonhandler.WithCodecs(map[string]jsonhandler.Codec{
+		"application/x-yaml": jsonhandler.YAMLCodec,
+	}))
+
+	tests := []struct {
+		name        string
+		contentType string
+		accept      string
+		body        string
+		wantType    string
+		want        string
+	}{
+		{
+			name:        "JSON request and response by default",
+			contentType: "application/json",
+			body:        `{"name": "Marcus"}`,
+			wantType:    "application/json; charset=utf-8",
+			want:        "{\n  \"name\": \"Marcus\"\n}\n",
+		},
+		{
+			name:        "YAML request and response when negotiated",
+			contentType: "application/x-yaml",
+			accept:      "application/x-yaml",
+			body:        "name: Marcus\n",
+			wantType:    "application/x-yaml",
+			want:        "name: Marcus\n",
+		},
+		{
+			name:        "YAML request, JSON response via Accept",
+			contentType: "application/x-yaml",
+			accept:      "application/json",
+			body:        "name: Marcus\n",
+			wantType:    "application/json; charset=utf-8",
+			want:        "{\n  \"name\": \"Marcus\"\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body))
+			r.Header.Set("content-type", tt.contentType)
+			if tt.accept != "" {
+				r.Header.Set("accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, r)
+			res := w.Result()
+
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("invalid status %d; want %d", res.StatusCode, http.StatusOK)
+			}
+			if ct := res.Header.Get("content-type"); ct != tt.wantType {
+				t.Errorf("content-type %q; want %q", ct, tt.wantType)
+			}
+			if body := w.Body.String(); body != tt.want {
+				t.Errorf("body %q; want %q", body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package jsonhandler
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlCodec struct{}
+
+func (yamlCodec) Decode(r io.Reader, v interface{}) error {
+	return yaml.NewDecoder(r).Decode(v)
+}
+
+func (yamlCodec) Encode(w io.Writer, v interface{}) error {
+	return yaml.NewEncoder(w).Encode(v)
+}
+
+func (yamlCodec) ContentType() string {
+	return "application/x-yaml"
+}
+
+// YAMLCodec encodes and decodes application/x-yaml payloads. Register it
+// with WithCodec to let a handler accept or produce YAML.
+var YAMLCodec Codec = yamlCodec{}
@@ -31,4 +31,31 @@ func Errorf(code int, format string, a ...interface{}) HandlerErr {
 	}
 }
 
+// WithCode attaches a machine-readable error code (e.g. "pet_not_found") to
+// h, surfaced as "code" in application/problem+json responses.
+func (h HandlerErr) WithCode(code string) HandlerErr {
+	h.errCode = code
+	return h
+}
+
+// WithDetail attaches a key/value pair of additional, machine-readable
+// detail to h, surfaced under "details" in application/problem+json
+// responses. Repeated calls accumulate rather than replace.
+func (h HandlerErr) WithDetail(key string, value interface{}) HandlerErr {
+	details := make(map[string]interface{}, len(h.details)+1)
+	for k, v := range h.details {
+		details[k] = v
+	}
+	details[key] = value
+	h.details = details
+	return h
+}
+
+// WithCauses attaches the errors that caused h, surfaced under "causes" in
+// application/problem+json responses. This is how ValidationError reports
+// per-field validation failures.
+func (h HandlerErr) WithCauses(causes ...HandlerErr) HandlerErr {
+	h.causes = append(append([]HandlerErr(nil), h.causes...), causes...)
+	return h
+}
 
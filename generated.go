@@ -0,0 +1,42 @@
+package jsonhandler
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// generatedHandlers holds handlers produced by jsonhandler-gen, keyed by the
+// entry point address of the function they were generated for. Generated
+// code registers itself from an init function via RegisterGenerated.
+var generatedHandlers sync.Map // map[uintptr]http.Handler
+
+// RegisterGenerated registers h as the zero-reflection replacement for fn.
+// NewHandler prefers a registered handler over the reflection-based
+// implementation whenever one has been registered for fn.
+//
+// RegisterGenerated is called from the init function of files produced by
+// the jsonhandler-gen tool, and is not meant to be called directly.
+func RegisterGenerated(fn interface{}, h http.Handler) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		panic("jsonhandler: RegisterGenerated called with a non-function value")
+	}
+	generatedHandlers.Store(v.Pointer(), h)
+}
+
+// lookupGenerated returns the handler registered for fn, if any. Go does not
+// relocate function code at runtime, so a function value's entry point
+// address is stable for the lifetime of the program and safe to use as a
+// lookup key.
+func lookupGenerated(fn interface{}) (http.Handler, bool) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return nil, false
+	}
+	h, ok := generatedHandlers.Load(v.Pointer())
+	if !ok {
+		return nil, false
+	}
+	return h.(http.Handler), true
+}
@@ -7,8 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // HandlerErr is the error type created by Error and Errorf.
@@ -16,6 +20,10 @@ type HandlerErr struct {
 	code    int
 	message string
 	err     error
+
+	errCode string
+	details map[string]interface{}
+	causes  []HandlerErr
 }
 
 type returnType int
@@ -43,12 +51,23 @@ type handler struct {
 	errN         int
 	optsN        int
 	outN         int
+	codecs       map[string]Codec
+	before       []RequestFunc
+	after        []ResponseFinalizer
+	errorEncoder ErrorEncoder
+	errCodes     []int
+	streamKind   streamKind
 }
 
 func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
-	ctx := r.Context()
-	ctx = context.WithValue(ctx, ctxKeyRequest, r)
+	ctx := WithRequest(r.Context(), r)
+	for _, fn := range h.before {
+		ctx = fn(ctx, r)
+	}
+
+	rw := &countingResponseWriter{ResponseWriter: w}
+	defer h.finalize(ctx, rw)
 
 	// construct arguments
 	var args []reflect.Value
@@ -57,18 +76,31 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if h.takesPayload {
 		pl := reflect.New(h.payloadType)
 
-		if err := json.NewDecoder(r.Body).Decode(pl.Interface()); err != nil {
+		if err := h.requestCodec(r).Decode(r.Body, pl.Interface()); err != nil {
 			defer r.Body.Close()
-			h.handleError(w, Errorf(http.StatusBadRequest, "Bad request: %w", err))
+			h.handleError(ctx, rw, r, Errorf(http.StatusBadRequest, "Bad request: %w", err))
 			return
 		}
 		_ = r.Body.Close()
+
+		if v, ok := pl.Interface().(Validator); ok {
+			if err := v.Validate(); err != nil {
+				h.handleError(ctx, rw, r, ValidationError(err))
+				return
+			}
+		}
+
 		args = append(args, pl.Elem())
 	}
 
 	// call the actual handler!
 	responses := h.hv.Call(args)
 
+	if h.streamKind != streamNone {
+		h.serveStream(ctx, rw, r, responses)
+		return
+	}
+
 	// collect responses
 	var (
 		out  interface{}
@@ -76,7 +108,7 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		opts []ResponseFunc
 	)
 	if h.outN < 0 && h.errN < 0 {
-		w.WriteHeader(http.StatusOK)
+		rw.WriteHeader(http.StatusOK)
 		return
 	}
 
@@ -94,10 +126,10 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(ctx, rw, r, err)
 		return
 	}
-	h.writeJSON(w, http.StatusOK, out, opts)
+	h.writeResponse(rw, r, http.StatusOK, out, opts)
 }
 
 // errResp is the output format of the errors returned by handler.ServeHTTP
@@ -105,24 +137,160 @@ type errResp struct {
 	Message string `json:"error"`
 }
 
+// finalize runs the handler's after-hooks now that the response is
+// complete.
+func (h handler) finalize(ctx context.Context, rw *countingResponseWriter) {
+	for _, fn := range h.after {
+		fn(ctx, rw.status(), rw.written)
+	}
+}
+
 // handleError correctly serializes and writes errors to the http.ResponseWriter.
-func (h handler) handleError(w http.ResponseWriter, err error) {
-	var (
-		jErr HandlerErr
-		msg  string
-		code = http.StatusInternalServerError
-	)
-	if errors.As(err, &jErr) {
-		msg = jErr.message
-		code = jErr.code
-	} else {
-		msg = err.Error()
+func (h handler) handleError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	if h.errorEncoder != nil {
+		h.errorEncoder(ctx, err, w)
+		return
 	}
-	h.writeJSON(w, code, errResp{Message: msg}, nil)
+
+	var jErr HandlerErr
+	if !errors.As(err, &jErr) {
+		jErr = Error(http.StatusInternalServerError, err.Error())
+	}
+
+	if acceptsProblemJSON(r) {
+		writeProblem(w, toProblem(r, jErr))
+		return
+	}
+
+	h.writeResponse(w, r, jErr.code, errResp{Message: jErr.message}, nil)
 }
 
-// writeJSON writes the JSON representation of the output from handler.fn.
-func (h handler) writeJSON(w http.ResponseWriter, code int, out interface{}, opts []ResponseFunc) {
+// writeResponse encodes out with the codec negotiated for r, applies opts,
+// and writes the result to w.
+func (h handler) writeResponse(w http.ResponseWriter, r *http.Request, code int, out interface{}, opts []ResponseFunc) {
+	c := h.responseCodec(r)
+
+	res := Response{
+		hd:         w.Header(),
+		StatusCode: code,
+	}
+
+	var buf bytes.Buffer
+	if err := c.Encode(&buf, out); err != nil {
+		http.Error(w, "Internal error: Encoding response failed", http.StatusInternalServerError)
+		return
+	}
+
+	for _, o := range opts {
+		o(&res)
+	}
+
+	w.Header().Set("content-type", c.ContentType())
+	for _, ck := range res.cookies {
+		http.SetCookie(w, ck)
+	}
+	w.WriteHeader(res.StatusCode)
+
+	_, _ = io.Copy(w, &buf)
+}
+
+// setCodec registers c under mediaType, initializing h.codecs if necessary.
+func (h *handler) setCodec(mediaType string, c Codec) {
+	if h.codecs == nil {
+		h.codecs = map[string]Codec{}
+	}
+	h.codecs[mediaType] = c
+}
+
+// ensureCodecs guarantees JSONCodec is available as a fallback, even when no
+// Option registered it explicitly.
+func (h *handler) ensureCodecs() {
+	if _, ok := h.codecs[mediaTypeOf(JSONCodec)]; !ok {
+		h.setCodec(mediaTypeOf(JSONCodec), JSONCodec)
+	}
+}
+
+func (h handler) defaultCodec() Codec {
+	if c, ok := h.codecs[mediaTypeOf(JSONCodec)]; ok {
+		return c
+	}
+	return JSONCodec
+}
+
+// requestCodec picks the Codec to decode r's body with, based on its
+// Content-Type header, falling back to defaultCodec.
+func (h handler) requestCodec(r *http.Request) Codec {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return h.defaultCodec()
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return h.defaultCodec()
+	}
+	if c, ok := h.codecs[mediaType]; ok {
+		return c
+	}
+	return h.defaultCodec()
+}
+
+// responseCodec negotiates the Codec to encode the response with, based on
+// r's Accept header, falling back to defaultCodec.
+func (h handler) responseCodec(r *http.Request) Codec {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return h.defaultCodec()
+	}
+	for _, mediaType := range parseAccept(accept) {
+		if mediaType == "*/*" {
+			return h.defaultCodec()
+		}
+		if c, ok := h.codecs[mediaType]; ok {
+			return c
+		}
+	}
+	return h.defaultCodec()
+}
+
+// parseAccept returns the media types in an Accept header, ordered from most
+// to least preferred according to their "q" parameters.
+func parseAccept(header string) []string {
+	type entry struct {
+		mediaType string
+		q         float64
+	}
+
+	parts := strings.Split(header, ",")
+	entries := make([]entry, 0, len(parts))
+	for _, p := range parts {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(p))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		entries = append(entries, entry{mediaType: mediaType, q: q})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].q > entries[j].q
+	})
+
+	mediaTypes := make([]string, len(entries))
+	for i, e := range entries {
+		mediaTypes[i] = e.mediaType
+	}
+	return mediaTypes
+}
+
+// WriteJSON writes out as a JSON response with the given status code, after
+// applying opts. It is exported so that handlers produced by jsonhandler-gen
+// can reuse the exact response-writing behavior of the reflection-based
+// handler without depending on the unexported handler type.
+func WriteJSON(w http.ResponseWriter, code int, out interface{}, opts ...ResponseFunc) {
 	res := Response{
 		hd:         w.Header(),
 		StatusCode: code,
@@ -149,6 +317,32 @@ func (h handler) writeJSON(w http.ResponseWriter, code int, out interface{}, opt
 	_, _ = io.Copy(w, &buf)
 }
 
+// WriteError writes err as a handler error response, mapping a HandlerErr's
+// status code and, when the request prefers application/problem+json,
+// its code/details/causes, the same way the reflection-based handler's
+// handleError does. It is exported for the same reason as WriteJSON.
+func WriteError(ctx context.Context, w http.ResponseWriter, err error) {
+	var jErr HandlerErr
+	if !errors.As(err, &jErr) {
+		jErr = Error(http.StatusInternalServerError, err.Error())
+	}
+
+	r := Request(ctx)
+	if acceptsProblemJSON(r) {
+		writeProblem(w, toProblem(r, jErr))
+		return
+	}
+
+	WriteJSON(w, jErr.code, errResp{Message: jErr.message})
+}
+
+// WithRequest stores r in ctx so that Request can later retrieve it. It is
+// exported so that handlers produced by jsonhandler-gen can expose the same
+// Request(ctx) behavior as the reflection-based handler.
+func WithRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, ctxKeyRequest, r)
+}
+
 // NewHandler creates a new handler. fn must be one of the following types:
 //
 //    func(context.Context)
@@ -159,6 +353,16 @@ func (h handler) writeJSON(w http.ResponseWriter, code int, out interface{}, opt
 //    func(context.Context, type) error
 //    func(context.Context, type) (type, error)
 //    func(context.Context, type) (type, []ResponseFunc, error)
+//    func(context.Context) (<-chan type, error)
+//    func(context.Context) (iter.Seq[type], error)
+//    func(context.Context, type) (<-chan type, error)
+//    func(context.Context, type) (iter.Seq[type], error)
+//
+// The last four shapes put the handler in streaming mode: instead of
+// buffering a single response, ServeHTTP writes one JSON value per element
+// as application/x-ndjson, or, if the client sent Accept: text/event-stream,
+// as server-sent events. Streaming stops when the channel or iterator is
+// exhausted or the request's context is canceled.
 //
 // fn is evaluated during NewHandler, and will panic if the handler does not
 // match any of the provided signatures. "type" may be any value that can be
@@ -176,13 +380,43 @@ func (h handler) writeJSON(w http.ResponseWriter, code int, out interface{}, opt
 //    {"error": "message"}
 //
 // To customize the status code, an error may be created using Errorf().
+//
+// NewHandler always speaks JSON; to register other codecs, or hooks, use
+// NewHandlerWith instead.
 func NewHandler(fn interface{}) http.Handler {
+	if gen, ok := lookupGenerated(fn); ok {
+		return gen
+	}
+
+	return NewHandlerWith(fn)
+}
+
+// NewHandlerWith behaves like NewHandler, but applies opts to the resulting
+// handler. Use WithCodec or WithCodecs to let the handler decode requests
+// and encode responses in something other than JSON, selected through
+// ordinary HTTP content negotiation (the request's Content-Type, and the
+// client's Accept header). Use WithBefore and WithAfter to run hooks before
+// the payload is decoded and after the response is written, and
+// WithErrorEncoder to replace the default error shape. Use WithErrorCodes to
+// declare the error statuses a handler may respond with when it is
+// registered with a Registry, so they appear in the generated OpenAPI
+// document.
+//
+// Unlike NewHandler, NewHandlerWith never returns a jsonhandler-gen'd
+// handler, since generated handlers only ever speak JSON and do not run
+// hooks.
+func NewHandlerWith(fn interface{}, opts ...Option) http.Handler {
 	h := &handler{fn: fn}
 
 	if err := parseHandler(h); err != nil {
 		panic(err)
 	}
 
+	for _, o := range opts {
+		o(h)
+	}
+	h.ensureCodecs()
+
 	return h
 }
 
@@ -227,6 +461,7 @@ func parseArgs(h *handler, ht reflect.Type) error {
 func parseReturns(h *handler, ht reflect.Type) error {
 	// reset these before proceeding (-1 indicates not present)
 	h.errN, h.outN, h.optsN = -1, -1, -1
+	h.streamKind = streamNone
 
 	n := ht.NumOut()
 	if n == 0 {
@@ -243,6 +478,8 @@ func parseReturns(h *handler, ht reflect.Type) error {
 			h.errN = i
 		case returnPayload:
 			h.outN = i
+			h.returnType = ht.Out(i)
+			h.streamKind = detectStream(ht.Out(i))
 		case returnOpts:
 			h.optsN = i
 		case returnInvalid:
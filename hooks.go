@@ -0,0 +1,25 @@
+package jsonhandler
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestFunc runs once per request, before the payload is decoded. It
+// receives the original ctx and request, and returns the context that will
+// be passed on to fn and to any ResponseFinalizer. This is the place to pull
+// auth tokens, trace IDs, or router path parameters (e.g. from chi or
+// gorilla/mux) into the context.
+type RequestFunc func(ctx context.Context, r *http.Request) context.Context
+
+// ResponseFinalizer runs once per request, after the response has been
+// fully written, with the status code and number of bytes written. It is
+// commonly used for metrics and access logging, and never affects the
+// response itself.
+type ResponseFinalizer func(ctx context.Context, code int, written int64)
+
+// ErrorEncoder writes err to w in place of jsonhandler's default
+// {"error": "..."} shape. Register one with WithErrorEncoder to change how
+// every handler built with that option renders errors, instead of
+// translating errors in every handler function.
+type ErrorEncoder func(ctx context.Context, err error, w http.ResponseWriter)
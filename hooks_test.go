@@ -0,0 +1,76 @@
+package jsonhandler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marcusirgens/jsonhandler"
+)
+
+func Test_hooksAndErrorEncoder(t *testing.T) {
+	type ctxKey struct{}
+
+	var (
+		beforeRan    bool
+		afterCode    int
+		afterWritten int64
+	)
+
+	h := jsonhandler.NewHandlerWith(func(ctx context.Context) (string, error) {
+		if ctx.Value(ctxKey{}) != "injected" {
+			t.Errorf("context value from WithBefore did not reach the handler")
+		}
+		return "ok", nil
+	},
+		jsonhandler.WithBefore(func(ctx context.Context, r *http.Request) context.Context {
+			beforeRan = true
+			return context.WithValue(ctx, ctxKey{}, "injected")
+		}),
+		jsonhandler.WithAfter(func(ctx context.Context, code int, written int64) {
+			afterCode = code
+			afterWritten = written
+		}),
+	)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(w, r)
+
+	if !beforeRan {
+		t.Error("WithBefore hook did not run")
+	}
+	if afterCode != http.StatusOK {
+		t.Errorf("WithAfter saw code %d; want %d", afterCode, http.StatusOK)
+	}
+	if afterWritten == 0 {
+		t.Error("WithAfter saw written == 0; want the encoded body length")
+	}
+}
+
+func Test_withErrorEncoder(t *testing.T) {
+	h := jsonhandler.NewHandlerWith(func(_ context.Context) error {
+		return jsonhandler.Errorf(http.StatusTeapot, "I'm a teapot")
+	}, jsonhandler.WithErrorEncoder(func(ctx context.Context, err error, w http.ResponseWriter) {
+		w.Header().Set("content-type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte(err.Error()))
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(w, r)
+	res := w.Result()
+
+	if res.StatusCode != http.StatusTeapot {
+		t.Errorf("status %d; want %d", res.StatusCode, http.StatusTeapot)
+	}
+	if ct := res.Header.Get("content-type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("content-type %q; want text/plain", ct)
+	}
+	if !strings.Contains(w.Body.String(), "I'm a teapot") {
+		t.Errorf("body %q does not contain the custom error encoder's output", w.Body.String())
+	}
+}
@@ -0,0 +1,61 @@
+package jsonhandler
+
+// Option configures a handler created by NewHandlerWith.
+type Option func(*handler)
+
+// WithCodec registers c under its own ContentType, for both decoding
+// requests and encoding responses. Registering a second Codec for a media
+// type that is already registered replaces the first.
+func WithCodec(c Codec) Option {
+	return func(h *handler) {
+		h.setCodec(mediaTypeOf(c), c)
+	}
+}
+
+// WithCodecs registers every Codec in codecs, keyed by media type. The key
+// is matched against a request's Content-Type and a client's Accept header;
+// it does not have to equal c.ContentType(), which lets a single Codec be
+// registered under an alias media type.
+func WithCodecs(codecs map[string]Codec) Option {
+	return func(h *handler) {
+		for mediaType, c := range codecs {
+			h.setCodec(mediaType, c)
+		}
+	}
+}
+
+// WithBefore appends fns to the handler's before-hook pipeline. Hooks run in
+// the order given, in the order successive WithBefore calls are applied, and
+// before the request payload is decoded.
+func WithBefore(fns ...RequestFunc) Option {
+	return func(h *handler) {
+		h.before = append(h.before, fns...)
+	}
+}
+
+// WithAfter appends fns to the handler's after-hook pipeline. Hooks run in
+// the order given, in the order successive WithAfter calls are applied,
+// after the response has been fully written.
+func WithAfter(fns ...ResponseFinalizer) Option {
+	return func(h *handler) {
+		h.after = append(h.after, fns...)
+	}
+}
+
+// WithErrorEncoder overrides the handler's default {"error": "..."} error
+// shape with enc.
+func WithErrorEncoder(enc ErrorEncoder) Option {
+	return func(h *handler) {
+		h.errorEncoder = enc
+	}
+}
+
+// WithErrorCodes declares the non-2xx status codes fn may respond with,
+// beyond the default 500 and, for handlers that take a payload, 400. A
+// Registry uses this to list the possible error responses for a route in
+// the OpenAPI document it generates. It has no effect on dispatch.
+func WithErrorCodes(codes ...int) Option {
+	return func(h *handler) {
+		h.errCodes = append(h.errCodes, codes...)
+	}
+}
@@ -0,0 +1,69 @@
+package jsonhandler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const problemJSONMediaType = "application/problem+json"
+
+// defaultProblemType is the "type" member RFC 7807 falls back to when a
+// problem has no more specific URI identifying its error type.
+const defaultProblemType = "about:blank"
+
+// problem is the application/problem+json representation of a HandlerErr,
+// per RFC 7807.
+type problem struct {
+	Type     string                 `json:"type,omitempty"`
+	Title    string                 `json:"title"`
+	Status   int                    `json:"status"`
+	Detail   string                 `json:"detail,omitempty"`
+	Instance string                 `json:"instance,omitempty"`
+	Code     string                 `json:"code,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+	Causes   []problem              `json:"causes,omitempty"`
+}
+
+// toProblem converts err into its application/problem+json representation,
+// setting instance from r's URL. Type is always "about:blank", per RFC
+// 7807, since jsonhandler does not maintain a registry of per-error-type
+// URIs; Code carries the more specific, machine-readable error identifier.
+func toProblem(r *http.Request, err HandlerErr) problem {
+	p := problem{
+		Type:    defaultProblemType,
+		Title:   http.StatusText(err.code),
+		Status:  err.code,
+		Detail:  err.message,
+		Code:    err.errCode,
+		Details: err.details,
+	}
+	if r != nil && r.URL != nil {
+		p.Instance = r.URL.Path
+	}
+	for _, cause := range err.causes {
+		p.Causes = append(p.Causes, toProblem(r, cause))
+	}
+	return p
+}
+
+// acceptsProblemJSON reports whether r's Accept header prefers
+// application/problem+json over jsonhandler's default {"error": "..."}
+// shape.
+func acceptsProblemJSON(r *http.Request) bool {
+	for _, mediaType := range parseAccept(r.Header.Get("Accept")) {
+		switch mediaType {
+		case problemJSONMediaType:
+			return true
+		case "application/json", "*/*":
+			return false
+		}
+	}
+	return false
+}
+
+// writeProblem writes p as an application/problem+json response.
+func writeProblem(w http.ResponseWriter, p problem) {
+	w.Header().Set("content-type", problemJSONMediaType+"; charset=utf-8")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,72 @@
+package jsonhandler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/marcusirgens/jsonhandler"
+)
+
+var signupValidate = validator.New()
+
+func Test_problemJSON(t *testing.T) {
+	h := jsonhandler.NewHandler(func(_ context.Context) error {
+		return jsonhandler.Error(http.StatusNotFound, "pet not found").WithCode("pet_not_found")
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/pets/42", nil)
+	r.Header.Set("accept", "application/problem+json")
+	h.ServeHTTP(w, r)
+	res := w.Result()
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("status %d; want %d", res.StatusCode, http.StatusNotFound)
+	}
+	if ct := res.Header.Get("content-type"); ct != "application/problem+json; charset=utf-8" {
+		t.Errorf("content-type %q; want application/problem+json", ct)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding response body: %v", err)
+	}
+	if body["type"] != "about:blank" {
+		t.Errorf(`type = %v; want "about:blank"`, body["type"])
+	}
+	if body["code"] != "pet_not_found" {
+		t.Errorf(`code = %v; want "pet_not_found"`, body["code"])
+	}
+	if body["instance"] != "/pets/42" {
+		t.Errorf(`instance = %v; want "/pets/42"`, body["instance"])
+	}
+}
+
+type signupPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (p *signupPayload) Validate() error {
+	return signupValidate.Struct(p)
+}
+
+func Test_validatorRejectsInvalidPayload(t *testing.T) {
+	h := jsonhandler.NewHandler(func(_ context.Context, in signupPayload) error {
+		t.Fatal("handler should not run for an invalid payload")
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"email": "not-an-email"}`))
+	h.ServeHTTP(w, r)
+	res := w.Result()
+
+	if res.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d; want %d", res.StatusCode, http.StatusUnprocessableEntity)
+	}
+}
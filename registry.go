@@ -0,0 +1,253 @@
+package jsonhandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// route is one method+path+handler triple registered with a Registry.
+type route struct {
+	method string
+	path   string
+	h      *handler
+}
+
+// Registry collects the routes created through Handle, so that
+// Registry.OpenAPI can derive a schema from the same payload and return
+// type information NewHandler already uses to dispatch requests.
+type Registry struct {
+	routes   []route
+	specPath string
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Handle parses fn exactly like NewHandlerWith, applying opts, registers it
+// under method and path, and returns the resulting http.Handler so it can be
+// wired into a router the same way NewHandler's result would be.
+func (reg *Registry) Handle(method, path string, fn interface{}, opts ...Option) http.Handler {
+	h := &handler{fn: fn}
+	if err := parseHandler(h); err != nil {
+		panic(err)
+	}
+	for _, o := range opts {
+		o(h)
+	}
+	h.ensureCodecs()
+
+	reg.routes = append(reg.routes, route{method: method, path: path, h: h})
+	return h
+}
+
+// OpenAPI walks every route registered with Handle and builds an OpenAPI 3
+// document describing it, using reflection over each handler's payload and
+// return types.
+func (reg *Registry) OpenAPI() (*openapi3.T, error) {
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:   "API",
+			Version: "0.0.0",
+		},
+		Paths: openapi3.Paths{},
+	}
+
+	for _, rt := range reg.routes {
+		op := &openapi3.Operation{
+			Responses: openapi3.NewResponses(),
+		}
+
+		if rt.h.takesPayload {
+			op.RequestBody = &openapi3.RequestBodyRef{
+				Value: openapi3.NewRequestBody().WithJSONSchema(schemaFor(rt.h.payloadType)),
+			}
+			op.Responses[strconv.Itoa(http.StatusBadRequest)] = &openapi3.ResponseRef{
+				Value: openapi3.NewResponse().WithDescription(http.StatusText(http.StatusBadRequest)),
+			}
+		}
+
+		if rt.h.outN >= 0 {
+			op.Responses[strconv.Itoa(http.StatusOK)] = &openapi3.ResponseRef{
+				Value: openapi3.NewResponse().
+					WithDescription(http.StatusText(http.StatusOK)).
+					WithJSONSchema(schemaFor(rt.h.returnType)),
+			}
+		} else {
+			op.Responses[strconv.Itoa(http.StatusOK)] = &openapi3.ResponseRef{
+				Value: openapi3.NewResponse().WithDescription(http.StatusText(http.StatusOK)),
+			}
+		}
+
+		op.Responses[strconv.Itoa(http.StatusInternalServerError)] = &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().WithDescription(http.StatusText(http.StatusInternalServerError)),
+		}
+
+		for _, code := range rt.h.errCodes {
+			op.Responses[strconv.Itoa(code)] = &openapi3.ResponseRef{
+				Value: openapi3.NewResponse().WithDescription(http.StatusText(code)),
+			}
+		}
+
+		item := doc.Paths[rt.path]
+		if item == nil {
+			item = &openapi3.PathItem{}
+			doc.Paths[rt.path] = item
+		}
+		item.SetOperation(rt.method, op)
+	}
+
+	return doc, nil
+}
+
+// ServeSpec returns an http.Handler serving the registry's OpenAPI document
+// as JSON, freshly generated on every request. It records path so a later
+// call to ServeSwaggerUI can point the UI at it.
+func (reg *Registry) ServeSpec(path string) http.Handler {
+	reg.specPath = path
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, err := reg.OpenAPI()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("content-type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(doc)
+	})
+}
+
+// ServeSwaggerUI returns an http.Handler serving a Swagger UI page that
+// fetches its spec from the path previously passed to ServeSpec. Call
+// ServeSpec before ServeSwaggerUI, or the UI defaults to fetching
+// "/openapi.json".
+func (reg *Registry) ServeSwaggerUI() http.Handler {
+	specPath := reg.specPath
+	if specPath == "" {
+		specPath = "/openapi.json"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprintf(w, swaggerUITemplate, specPath)
+	})
+}
+
+const swaggerUITemplate = `<!DOCTYPE html>
+<html>
+<head>
+  <title>API docs</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      SwaggerUIBundle({url: %q, dom_id: "swagger-ui"})
+    }
+  </script>
+</body>
+</html>
+`
+
+// schemaFor derives an OpenAPI schema from a Go type via reflection,
+// following the same json struct tags encoding/json uses, plus "example"
+// and "description" tags for documentation.
+func schemaFor(t reflect.Type) *openapi3.Schema {
+	return schemaForType(t, map[reflect.Type]bool{})
+}
+
+// schemaForType is schemaFor's recursive worker. visiting holds the struct
+// types currently being described higher up the call stack; a type that
+// refers back to one of them (e.g. a tree or linked-list node) is rendered
+// as a plain, un-expanded object instead of being walked again, so
+// self-referential payload and return types can't recurse forever.
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) *openapi3.Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return openapi3.NewStringSchema()
+	case reflect.Bool:
+		return openapi3.NewBoolSchema()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return openapi3.NewIntegerSchema()
+	case reflect.Float32, reflect.Float64:
+		return openapi3.NewFloat64Schema()
+	case reflect.Slice, reflect.Array:
+		return openapi3.NewArraySchema().WithItems(schemaForType(t.Elem(), visiting))
+	case reflect.Map:
+		return openapi3.NewObjectSchema().WithAdditionalProperties(schemaForType(t.Elem(), visiting))
+	case reflect.Struct:
+		if visiting[t] {
+			s := openapi3.NewObjectSchema()
+			s.Description = fmt.Sprintf("%s (recursive type, not expanded further)", t.Name())
+			return s
+		}
+		visiting[t] = true
+		s := structSchema(t, visiting)
+		delete(visiting, t)
+		return s
+	default:
+		return openapi3.NewSchema()
+	}
+}
+
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) *openapi3.Schema {
+	s := openapi3.NewObjectSchema()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			// unexported fields are never marshaled by encoding/json.
+			continue
+		}
+
+		name, omitempty := jsonFieldName(f)
+		if name == "-" {
+			continue
+		}
+
+		fs := schemaForType(f.Type, visiting)
+		if example, ok := f.Tag.Lookup("example"); ok {
+			fs.Example = example
+		}
+		if desc, ok := f.Tag.Lookup("description"); ok {
+			fs.Description = desc
+		}
+
+		s = s.WithProperty(name, fs)
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// jsonFieldName mirrors encoding/json's struct tag rules closely enough for
+// schema generation: a "-" tag name means the field is never (un)marshaled,
+// and "omitempty" means the field is not required.
+func jsonFieldName(f reflect.StructField) (name string, omitempty bool) {
+	tag := f.Tag.Get("json")
+	parts := strings.Split(tag, ",")
+
+	name = f.Name
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	for _, p := range parts[1:] {
+		if p == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
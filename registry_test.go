@@ -0,0 +1,59 @@
+package jsonhandler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type recursiveNode struct {
+	Value    string          `json:"value"`
+	Children []recursiveNode `json:"children,omitempty"`
+}
+
+func Test_schemaFor_recursiveType(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("schemaFor recursed instead of stopping at the repeated type: %v", r)
+		}
+	}()
+
+	s := schemaFor(reflect.TypeOf(recursiveNode{}))
+	if s == nil {
+		t.Fatal("schemaFor returned nil")
+	}
+	if _, ok := s.Properties["children"]; !ok {
+		t.Fatal("expected a \"children\" property in the schema")
+	}
+}
+
+func Test_Registry_OpenAPI(t *testing.T) {
+	reg := NewRegistry()
+	reg.Handle(http.MethodPost, "/greet", func(_ context.Context, in string) (string, error) {
+		return "hello " + in, nil
+	})
+
+	doc, err := reg.OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI() returned an error: %v", err)
+	}
+
+	item := doc.Paths["/greet"]
+	if item == nil {
+		t.Fatal("expected a path item for /greet")
+	}
+	if item.Post == nil {
+		t.Fatal("expected a POST operation for /greet")
+	}
+	if item.Post.RequestBody == nil {
+		t.Error("expected a request body schema for the string payload")
+	}
+	if _, ok := item.Post.Responses[strconv.Itoa(http.StatusOK)]; !ok {
+		t.Error("expected a 200 response")
+	}
+	if _, ok := item.Post.Responses[strconv.Itoa(http.StatusInternalServerError)]; !ok {
+		t.Error("expected a default 500 response, as documented by WithErrorCodes")
+	}
+}
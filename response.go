@@ -23,3 +23,42 @@ func (r *Response) SetCookie(cookie *http.Cookie) {
 // by NewHandler returns.
 type ResponseFunc func(r *Response)
 
+// countingResponseWriter wraps an http.ResponseWriter to track the status
+// code and number of bytes written, so that a ResponseFinalizer can observe
+// them once the response is complete.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	code    int
+	written int64
+}
+
+func (w *countingResponseWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *countingResponseWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// status returns the status code that was written, or http.StatusOK if none
+// was written explicitly.
+func (w *countingResponseWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+// Unwrap exposes the wrapped http.ResponseWriter so that http.ResponseController
+// can reach a Flusher (or other optional interfaces) implemented by it, as
+// countingResponseWriter itself only implements http.ResponseWriter.
+func (w *countingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
@@ -0,0 +1,178 @@
+package jsonhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// streamKind identifies the shape of a handler's streaming return value, if
+// any.
+type streamKind int
+
+const (
+	streamNone streamKind = iota
+	streamChan
+	streamIterSeq
+)
+
+// sseKeepAliveInterval is how often writeSSE emits a keep-alive comment
+// while waiting for the next element.
+const sseKeepAliveInterval = 15 * time.Second
+
+// detectStream reports whether t is one of the streaming return shapes
+// jsonhandler recognizes: a receive channel, or an iter.Seq.
+func detectStream(t reflect.Type) streamKind {
+	if t.Kind() == reflect.Chan && t.ChanDir()&reflect.RecvDir != 0 {
+		return streamChan
+	}
+	if t.Kind() == reflect.Func && strings.HasPrefix(t.String(), "iter.Seq[") {
+		return streamIterSeq
+	}
+	return streamNone
+}
+
+// serveStream handles a call to a streaming handler: it propagates fn's
+// error return as usual, then bridges the channel or iter.Seq it returned
+// into an ndjson or SSE response, depending on r's Accept header.
+func (h handler) serveStream(ctx context.Context, w http.ResponseWriter, r *http.Request, responses []reflect.Value) {
+	if h.errN >= 0 {
+		if v, ok := responses[h.errN].Interface().(error); ok && v != nil {
+			h.handleError(ctx, w, r, v)
+			return
+		}
+	}
+
+	elems := h.bridgeStream(ctx, responses[h.outN])
+
+	if acceptsEventStream(r) {
+		writeSSE(ctx, w, elems)
+		return
+	}
+	writeNDJSON(ctx, w, elems)
+}
+
+// bridgeStream adapts a channel or iter.Seq return value into a
+// <-chan reflect.Value, so writeNDJSON and writeSSE can consume either
+// uniformly alongside a keep-alive ticker. Iteration stops, and the
+// returned channel is closed, once ctx is done.
+func (h handler) bridgeStream(ctx context.Context, stream reflect.Value) <-chan reflect.Value {
+	out := make(chan reflect.Value)
+
+	go func() {
+		defer close(out)
+
+		switch h.streamKind {
+		case streamChan:
+			cases := []reflect.SelectCase{
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+				{Dir: reflect.SelectRecv, Chan: stream},
+			}
+			for {
+				chosen, v, ok := reflect.Select(cases)
+				if chosen == 0 || !ok {
+					return
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		case streamIterSeq:
+			yield := reflect.MakeFunc(stream.Type().In(0), func(args []reflect.Value) []reflect.Value {
+				select {
+				case out <- args[0]:
+					return []reflect.Value{reflect.ValueOf(ctx.Err() == nil)}
+				case <-ctx.Done():
+					return []reflect.Value{reflect.ValueOf(false)}
+				}
+			})
+			stream.Call([]reflect.Value{yield})
+		}
+	}()
+
+	return out
+}
+
+// writeNDJSON writes one JSON value per line, flushing after each one,
+// until elems is exhausted or ctx is done.
+func writeNDJSON(ctx context.Context, w http.ResponseWriter, elems <-chan reflect.Value) {
+	w.Header().Set("content-type", "application/x-ndjson; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-elems:
+			if !ok {
+				return
+			}
+			if err := enc.Encode(v.Interface()); err != nil {
+				return
+			}
+			_ = rc.Flush()
+		}
+	}
+}
+
+// writeSSE writes elems as server-sent events, emitting a keep-alive
+// comment every sseKeepAliveInterval while waiting for the next one, until
+// elems is exhausted or ctx is done.
+func writeSSE(ctx context.Context, w http.ResponseWriter, elems <-chan reflect.Value) {
+	w.Header().Set("content-type", "text/event-stream; charset=utf-8")
+	w.Header().Set("cache-control", "no-cache")
+	w.Header().Set("connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			_ = rc.Flush()
+		case v, ok := <-elems:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(v.Interface())
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			_ = rc.Flush()
+		}
+	}
+}
+
+// acceptsEventStream reports whether r's Accept header prefers
+// text/event-stream over jsonhandler's default application/x-ndjson
+// streaming format.
+func acceptsEventStream(r *http.Request) bool {
+	for _, mediaType := range parseAccept(r.Header.Get("Accept")) {
+		switch mediaType {
+		case "text/event-stream":
+			return true
+		case "application/x-ndjson", "application/json", "*/*":
+			return false
+		}
+	}
+	return false
+}
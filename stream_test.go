@@ -0,0 +1,145 @@
+package jsonhandler_test
+
+import (
+	"bufio"
+	"context"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcusirgens/jsonhandler"
+)
+
+// flushRecorder wraps httptest.NewRecorder's writer, additionally counting
+// how many times Flush is called, so tests can assert that a streaming
+// handler actually flushes after every element instead of buffering the
+// whole response.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (w *flushRecorder) Flush() {
+	w.flushes++
+}
+
+func Test_streamingHandler_flushesThroughCountingResponseWriter(t *testing.T) {
+	h := jsonhandler.NewHandler(func(_ context.Context) (<-chan string, error) {
+		out := make(chan string, 2)
+		out <- "one"
+		out <- "two"
+		close(out)
+		return out, nil
+	})
+
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(w, r)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ServeHTTP did not return")
+	}
+
+	if w.flushes == 0 {
+		t.Error("expected at least one Flush call; the response controller never reached the underlying Flusher")
+	}
+	if ct := w.Result().Header.Get("content-type"); !strings.HasPrefix(ct, "application/x-ndjson") {
+		t.Errorf("content-type %q; want application/x-ndjson", ct)
+	}
+
+	lines := bufio.NewScanner(w.Body)
+	var got []string
+	for lines.Scan() {
+		got = append(got, lines.Text())
+	}
+	if len(got) != 2 || got[0] != `"one"` || got[1] != `"two"` {
+		t.Errorf("got lines %v; want [%q %q]", got, `"one"`, `"two"`)
+	}
+}
+
+func Test_streamingHandler_SSE(t *testing.T) {
+	h := jsonhandler.NewHandler(func(_ context.Context) (<-chan string, error) {
+		out := make(chan string, 2)
+		out <- "one"
+		out <- "two"
+		close(out)
+		return out, nil
+	})
+
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("accept", "text/event-stream")
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(w, r)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ServeHTTP did not return")
+	}
+
+	if ct := w.Result().Header.Get("content-type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("content-type %q; want text/event-stream", ct)
+	}
+	if w.flushes == 0 {
+		t.Error("expected at least one Flush call for the SSE stream")
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "data: \"one\"\n\n") || !strings.Contains(body, "data: \"two\"\n\n") {
+		t.Errorf("body %q does not contain the expected data: frames", body)
+	}
+}
+
+func Test_streamingHandler_iterSeq(t *testing.T) {
+	h := jsonhandler.NewHandler(func(_ context.Context) (iter.Seq[string], error) {
+		return func(yield func(string) bool) {
+			if !yield("one") {
+				return
+			}
+			yield("two")
+		}, nil
+	})
+
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(w, r)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ServeHTTP did not return")
+	}
+
+	if w.flushes == 0 {
+		t.Error("expected at least one Flush call for the iter.Seq stream")
+	}
+
+	lines := bufio.NewScanner(w.Body)
+	var got []string
+	for lines.Scan() {
+		got = append(got, lines.Text())
+	}
+	if len(got) != 2 || got[0] != `"one"` || got[1] != `"two"` {
+		t.Errorf("got lines %v; want [%q %q]", got, `"one"`, `"two"`)
+	}
+}
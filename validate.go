@@ -0,0 +1,41 @@
+package jsonhandler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validator is implemented by payload types that want automatic validation
+// once they have been decoded. If Validate returns an error, ServeHTTP
+// responds with 422 Unprocessable Entity and never calls fn.
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError wraps err, returned from a payload's Validate method, as a
+// 422 HandlerErr. If err is (or wraps) a validator.ValidationErrors, each
+// field error becomes a cause with "field" and "tag" details.
+//
+// It is exported so that handlers generated by jsonhandler-gen, which live
+// outside this package, can report a Validate failure the same way the
+// reflection-based ServeHTTP does.
+func ValidationError(err error) HandlerErr {
+	he := Errorf(http.StatusUnprocessableEntity, "Validation failed: %w", err).
+		WithCode("validation_failed")
+
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		for _, fe := range fieldErrs {
+			he = he.WithCauses(
+				Error(http.StatusUnprocessableEntity, fe.Error()).
+					WithCode("invalid_field").
+					WithDetail("field", fe.Field()).
+					WithDetail("tag", fe.Tag()),
+			)
+		}
+	}
+
+	return he
+}
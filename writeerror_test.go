@@ -0,0 +1,66 @@
+package jsonhandler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marcusirgens/jsonhandler"
+)
+
+// Test_WriteError_honorsProblemJSON guards against a regression where
+// generated (zero-reflection) handlers from jsonhandler-gen lost the
+// problem+json/validation-error reporting that the reflection-based
+// ServeHTTP provides, because WriteError never looked at the request's
+// Accept header or carried errCode/details/causes through toProblem.
+func Test_WriteError_honorsProblemJSON(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/signup", nil)
+	r.Header.Set("accept", "application/problem+json")
+	ctx := jsonhandler.WithRequest(r.Context(), r)
+
+	w := httptest.NewRecorder()
+	jsonhandler.WriteError(ctx, w, jsonhandler.
+		Errorf(http.StatusUnprocessableEntity, "Validation failed: email is required").
+		WithCode("validation_failed"))
+	res := w.Result()
+
+	if res.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d; want %d", res.StatusCode, http.StatusUnprocessableEntity)
+	}
+	if ct := res.Header.Get("content-type"); ct != "application/problem+json; charset=utf-8" {
+		t.Fatalf("content-type %q; want application/problem+json", ct)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding response body: %v", err)
+	}
+	if body["code"] != "validation_failed" {
+		t.Errorf(`code = %v; want "validation_failed"`, body["code"])
+	}
+}
+
+func Test_WriteError_defaultsToErrorShape(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/signup", nil)
+	ctx := jsonhandler.WithRequest(r.Context(), r)
+
+	w := httptest.NewRecorder()
+	jsonhandler.WriteError(ctx, w, jsonhandler.Errorf(http.StatusBadGateway, "Bad gateway"))
+	res := w.Result()
+
+	if res.StatusCode != http.StatusBadGateway {
+		t.Fatalf("status %d; want %d", res.StatusCode, http.StatusBadGateway)
+	}
+	if ct := res.Header.Get("content-type"); ct != "application/json; charset=utf-8" {
+		t.Fatalf("content-type %q; want application/json", ct)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding response body: %v", err)
+	}
+	if body["error"] != "Bad gateway" {
+		t.Errorf(`error = %v; want "Bad gateway"`, body["error"])
+	}
+}